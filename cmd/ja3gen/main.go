@@ -0,0 +1,111 @@
+// Command ja3gen regenerates fingerprints.json from a directory of captured
+// mirror.Record JSON files (see package mirror), one fingerprint entry per
+// --name/--version pair requested.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tfyl/ja3"
+	"github.com/tfyl/ja3/mirror"
+)
+
+func main() {
+	dir := flag.String("dir", "", "directory of captured mirror.Record JSON files")
+	name := flag.String("name", "", "fingerprint name, e.g. Chrome")
+	version := flag.String("version", "", "fingerprint version, e.g. 124")
+	out := flag.String("out", "fingerprints.json", "dataset file to update")
+	flag.Parse()
+	if *dir == "" || *name == "" || *version == "" {
+		log.Fatal("ja3gen: -dir, -name and -version are required")
+	}
+
+	records, err := readRecords(*dir)
+	if err != nil {
+		log.Fatalf("ja3gen: reading captures: %v", err)
+	}
+	if len(records) == 0 {
+		log.Fatalf("ja3gen: no captures found in %s", *dir)
+	}
+	entry := buildEntry(*name, *version, records[0])
+
+	db := readDataset(*out)
+	db = upsertEntry(db, entry)
+	writeDataset(*out, db)
+}
+
+func readRecords(dir string) ([]mirror.Record, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	records := []mirror.Record{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var record mirror.Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func buildEntry(name, version string, record mirror.Record) ja3.FingerprintEntry {
+	return ja3.FingerprintEntry{
+		Name:       name,
+		Version:    version,
+		JA3:        record.JA3,
+		JA3N:       record.JA3N,
+		JA4:        record.JA4,
+		Ciphers:    record.TlsData.Ciphers,
+		Extensions: record.TlsData.Extensions,
+		Curves:     record.TlsData.Curves,
+		Points:     record.TlsData.Points,
+		Protocols:  record.TlsData.Protocols,
+		Versions:   record.TlsData.Versions,
+		Algorithms: record.TlsData.Algorithms,
+	}
+}
+
+func readDataset(path string) []ja3.FingerprintEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []ja3.FingerprintEntry{}
+	}
+	var db []ja3.FingerprintEntry
+	if err := json.Unmarshal(data, &db); err != nil {
+		log.Fatalf("ja3gen: parsing existing dataset: %v", err)
+	}
+	return db
+}
+
+func upsertEntry(db []ja3.FingerprintEntry, entry ja3.FingerprintEntry) []ja3.FingerprintEntry {
+	for i, existing := range db {
+		if existing.Name == entry.Name && existing.Version == entry.Version {
+			db[i] = entry
+			return db
+		}
+	}
+	return append(db, entry)
+}
+
+func writeDataset(path string, db []ja3.FingerprintEntry) {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		log.Fatalf("ja3gen: encoding dataset: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatalf("ja3gen: writing %s: %v", path, err)
+	}
+}
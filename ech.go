@@ -0,0 +1,249 @@
+package ja3
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/hkdf"
+)
+
+const extTypeECH uint16 = 0xfe0d //encrypted_client_hello
+
+const (
+	hpkeKemX25519HKDFSHA256 uint16 = 0x0020
+	hpkeKdfHKDFSHA256       uint16 = 0x0001
+	hpkeAeadAES128GCM       uint16 = 0x0001
+	hpkeAeadAES256GCM       uint16 = 0x0002
+	hpkeAeadChaCha20Poly    uint16 = 0x0003
+)
+
+// ECHClientHelloInfo is the decoded encrypted_client_hello extension. Outer
+// is false for the inner/GREASE placeholder (an empty payload), true for the
+// real outer hello carrying the HPKE-encrypted ClientHelloInner.
+type ECHClientHelloInfo struct {
+	Outer    bool
+	ConfigId uint8
+	KdfId    uint16
+	AeadId   uint16
+	Enc      []byte
+	Payload  []byte
+}
+
+func parseECH(data cryptobyte.String) (ECHClientHelloInfo, error) {
+	var typ uint8
+	if !data.ReadUint8(&typ) {
+		return ECHClientHelloInfo{}, errors.New("ech: type error")
+	}
+	if typ != 0 {
+		return ECHClientHelloInfo{Outer: false}, nil
+	}
+	info := ECHClientHelloInfo{Outer: true}
+	if !data.ReadUint16(&info.KdfId) {
+		return info, errors.New("ech: kdf_id error")
+	}
+	if !data.ReadUint16(&info.AeadId) {
+		return info, errors.New("ech: aead_id error")
+	}
+	if !data.ReadUint8(&info.ConfigId) {
+		return info, errors.New("ech: config_id error")
+	}
+	var enc, payload cryptobyte.String
+	if !data.ReadUint16LengthPrefixed(&enc) {
+		return info, errors.New("ech: enc error")
+	}
+	info.Enc = append([]byte{}, enc...)
+	if !data.ReadUint16LengthPrefixed(&payload) {
+		return info, errors.New("ech: payload error")
+	}
+	info.Payload = append([]byte{}, payload...)
+	return info, nil
+}
+
+// ECH returns the decoded encrypted_client_hello extension, if present.
+func (obj ClientHello) ECH() (*ECHClientHelloInfo, bool) {
+	for _, ext := range obj.Extensions {
+		if ext.Type == extTypeECH {
+			info, err := parseECH(ext.Data)
+			if err != nil {
+				return nil, false
+			}
+			return &info, true
+		}
+	}
+	return nil, false
+}
+
+// ECHKeyPair is the HPKE receiver key used to decrypt an outer ECH payload
+// into its ClientHelloInner. Only the mandatory-to-implement
+// DHKEM(X25519, HKDF-SHA256) KEM is supported.
+type ECHKeyPair struct {
+	ConfigId   uint8
+	PrivateKey []byte //raw 32-byte X25519 scalar matching the ECHConfig's public key
+	Info       []byte //HPKE info: "tls ech" || 0x00 || the raw ECHConfig this ClientHello targeted
+}
+
+// DecryptECH decrypts the outer ECH payload and decodes the resulting
+// ClientHelloInner, so callers can fingerprint the true hello alongside the
+// decoy outer one.
+func (obj *FpContextData) DecryptECH(keypair ECHKeyPair) (ClientHello, error) {
+	clientHello, err := obj.ClientHello()
+	if err != nil {
+		return ClientHello{}, err
+	}
+	echInfo, ok := clientHello.ECH()
+	if !ok || !echInfo.Outer {
+		return ClientHello{}, errors.New("ech: client hello has no outer ECH extension")
+	}
+	if echInfo.ConfigId != keypair.ConfigId {
+		return ClientHello{}, fmt.Errorf("ech: config_id mismatch: got %d want %d", echInfo.ConfigId, keypair.ConfigId)
+	}
+	if echInfo.KdfId != hpkeKdfHKDFSHA256 {
+		return ClientHello{}, fmt.Errorf("ech: unsupported kdf_id %#04x", echInfo.KdfId)
+	}
+
+	offset := bytes.Index(obj.clientHelloData, echInfo.Payload)
+	if offset < 0 {
+		return ClientHello{}, errors.New("ech: could not locate payload within the raw client hello")
+	}
+	aad := append([]byte{}, obj.clientHelloData...)
+	for i := range echInfo.Payload {
+		aad[offset+i] = 0
+	}
+
+	plaintext, err := hpkeOpenBaseX25519SHA256(keypair.PrivateKey, echInfo.Enc, echInfo.AeadId, keypair.Info, aad, echInfo.Payload)
+	if err != nil {
+		return ClientHello{}, fmt.Errorf("ech: hpke open: %w", err)
+	}
+	return decodeClientHello(wrapHandshakeRecord(plaintext))
+}
+
+// wrapHandshakeRecord re-wraps an EncodedClientHelloInner body (legacy
+// version through extensions, with no record/handshake header of its own)
+// in a synthetic TLS record + handshake header so decodeClientHello can
+// parse it unmodified.
+func wrapHandshakeRecord(body []byte) []byte {
+	handshake := make([]byte, 4+len(body))
+	handshake[0] = 1 //client_hello
+	handshake[1] = byte(len(body) >> 16)
+	handshake[2] = byte(len(body) >> 8)
+	handshake[3] = byte(len(body))
+	copy(handshake[4:], body)
+
+	record := make([]byte, 5+len(handshake))
+	record[0] = 0x16 //handshake content type
+	record[1] = 0x03
+	record[2] = 0x01
+	record[3] = byte(len(handshake) >> 8)
+	record[4] = byte(len(handshake))
+	copy(record[5:], handshake)
+	return record
+}
+
+// hpkeOpenBaseX25519SHA256 implements RFC 9180 base-mode HPKE Open for the
+// DHKEM(X25519, HKDF-SHA256) KEM with HKDF-SHA256, the suite ECH requires.
+func hpkeOpenBaseX25519SHA256(privateKey, enc []byte, aeadId uint16, info, aad, ciphertext []byte) ([]byte, error) {
+	curve := ecdh.X25519()
+	priv, err := curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	pubE, err := curve.NewPublicKey(enc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enc: %w", err)
+	}
+	dh, err := priv.ECDH(pubE)
+	if err != nil {
+		return nil, fmt.Errorf("dh: %w", err)
+	}
+	pkR := priv.PublicKey().Bytes()
+
+	kemSuiteID := append([]byte("KEM"), be16(hpkeKemX25519HKDFSHA256)...)
+	eaePrk := labeledExtract(nil, kemSuiteID, []byte("eae_prk"), dh)
+	kemContext := append(append([]byte{}, enc...), pkR...)
+	sharedSecret := labeledExpand(eaePrk, kemSuiteID, []byte("shared_secret"), kemContext, 32)
+
+	hpkeSuiteID := append([]byte("HPKE"), be16(hpkeKemX25519HKDFSHA256)...)
+	hpkeSuiteID = append(hpkeSuiteID, be16(hpkeKdfHKDFSHA256)...)
+	hpkeSuiteID = append(hpkeSuiteID, be16(aeadId)...)
+
+	pskIdHash := labeledExtract(nil, hpkeSuiteID, []byte("psk_id_hash"), nil)
+	infoHash := labeledExtract(nil, hpkeSuiteID, []byte("info_hash"), info)
+	keyScheduleContext := append([]byte{0x00}, pskIdHash...) //mode_base
+	keyScheduleContext = append(keyScheduleContext, infoHash...)
+
+	secret := labeledExtract(sharedSecret, hpkeSuiteID, []byte("secret"), nil)
+
+	keyLen, nonceLen, err := aeadSizes(aeadId)
+	if err != nil {
+		return nil, err
+	}
+	key := labeledExpand(secret, hpkeSuiteID, []byte("key"), keyScheduleContext, keyLen)
+	nonce := labeledExpand(secret, hpkeSuiteID, []byte("base_nonce"), keyScheduleContext, nonceLen)
+
+	aead, err := newAEAD(aeadId, key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func aeadSizes(aeadId uint16) (keyLen, nonceLen int, err error) {
+	switch aeadId {
+	case hpkeAeadAES128GCM:
+		return 16, 12, nil
+	case hpkeAeadAES256GCM:
+		return 32, 12, nil
+	case hpkeAeadChaCha20Poly:
+		return 32, 12, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported aead_id %#04x", aeadId)
+	}
+}
+
+func newAEAD(aeadId uint16, key []byte) (cipher.AEAD, error) {
+	switch aeadId {
+	case hpkeAeadAES128GCM, hpkeAeadAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case hpkeAeadChaCha20Poly:
+		return chacha20poly1305.New(key)
+	default:
+		return nil, fmt.Errorf("unsupported aead_id %#04x", aeadId)
+	}
+}
+
+func be16(value uint16) []byte {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, value)
+	return buf
+}
+
+func labeledExtract(salt, suiteID, label, ikm []byte) []byte {
+	labeledIkm := append([]byte("HPKE-v1"), suiteID...)
+	labeledIkm = append(labeledIkm, label...)
+	labeledIkm = append(labeledIkm, ikm...)
+	return hkdf.Extract(sha256.New, labeledIkm, salt)
+}
+
+func labeledExpand(prk, suiteID, label, info []byte, length int) []byte {
+	labeledInfo := be16(uint16(length))
+	labeledInfo = append(labeledInfo, []byte("HPKE-v1")...)
+	labeledInfo = append(labeledInfo, suiteID...)
+	labeledInfo = append(labeledInfo, label...)
+	labeledInfo = append(labeledInfo, info...)
+	out := make([]byte, length)
+	io.ReadFull(hkdf.Expand(sha256.New, prk, labeledInfo), out)
+	return out
+}
@@ -0,0 +1,155 @@
+package ja3
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+)
+
+// sealECHForTest is the encrypting counterpart of hpkeOpenBaseX25519SHA256,
+// used only to build a self-consistent round-trip fixture for DecryptECH.
+// The caller must supply aad computed with this privE's enc already embedded
+// in the outer record (and the payload field still zeroed), exactly as a
+// real ECH client would before it fills in the ciphertext.
+func sealECHForTest(t *testing.T, privE *ecdh.PrivateKey, pubR *ecdh.PublicKey, aeadId uint16, info, aad, plaintext []byte) (ciphertext []byte) {
+	t.Helper()
+	dh, err := privE.ECDH(pubR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := privE.PublicKey().Bytes()
+	pkR := pubR.Bytes()
+
+	kemSuiteID := append([]byte("KEM"), be16(hpkeKemX25519HKDFSHA256)...)
+	eaePrk := labeledExtract(nil, kemSuiteID, []byte("eae_prk"), dh)
+	kemContext := append(append([]byte{}, enc...), pkR...)
+	sharedSecret := labeledExpand(eaePrk, kemSuiteID, []byte("shared_secret"), kemContext, 32)
+
+	hpkeSuiteID := append([]byte("HPKE"), be16(hpkeKemX25519HKDFSHA256)...)
+	hpkeSuiteID = append(hpkeSuiteID, be16(hpkeKdfHKDFSHA256)...)
+	hpkeSuiteID = append(hpkeSuiteID, be16(aeadId)...)
+
+	pskIdHash := labeledExtract(nil, hpkeSuiteID, []byte("psk_id_hash"), nil)
+	infoHash := labeledExtract(nil, hpkeSuiteID, []byte("info_hash"), info)
+	keyScheduleContext := append([]byte{0x00}, pskIdHash...)
+	keyScheduleContext = append(keyScheduleContext, infoHash...)
+
+	secret := labeledExtract(sharedSecret, hpkeSuiteID, []byte("secret"), nil)
+	keyLen, nonceLen, err := aeadSizes(aeadId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := labeledExpand(secret, hpkeSuiteID, []byte("key"), keyScheduleContext, keyLen)
+	nonce := labeledExpand(secret, hpkeSuiteID, []byte("base_nonce"), keyScheduleContext, nonceLen)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead.Seal(nil, nonce, plaintext, aad)
+}
+
+func echExtensionData(configId uint8, kdfId, aeadId uint16, enc, payload []byte) []byte {
+	var out []byte
+	out = append(out, 0x00) //outer
+	out = append(out, byte(kdfId>>8), byte(kdfId))
+	out = append(out, byte(aeadId>>8), byte(aeadId))
+	out = append(out, configId)
+	out = append(out, byte(len(enc)>>8), byte(len(enc)))
+	out = append(out, enc...)
+	out = append(out, byte(len(payload)>>8), byte(len(payload)))
+	out = append(out, payload...)
+	return out
+}
+
+// TestDecryptECHRoundTrip exercises the full outer-ClientHello -> HPKE open
+// -> inner-ClientHello path: it seals a real ClientHelloInner with a freshly
+// generated HPKE receiver key, embeds it in a synthetic outer ClientHello
+// exactly as DecryptECH expects to find it (AAD with the payload zeroed),
+// and asserts the decrypted inner hello carries the inner cipher suites and
+// extensions.
+func TestDecryptECHRoundTrip(t *testing.T) {
+	curve := ecdh.X25519()
+	privR, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	innerBody := buildClientHelloInnerBody(
+		[]uint16{0x1301, 0x1302},
+		[]Extension{
+			{Type: 0x0000, Data: sniExtensionData("inner.example.com")},
+		},
+	)
+
+	info := append([]byte("tls ech"), 0x00)
+	info = append(info, []byte("mock-ech-config")...)
+	aeadId := hpkeAeadAES128GCM
+	configId := uint8(7)
+
+	privE, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := privE.PublicKey().Bytes()
+
+	//ciphertext length (plaintext + AEAD tag) is known ahead of sealing, so
+	//build the outer record with the real enc already in place and a
+	//placeholder payload of the right size. The placeholder uses a
+	//distinctive non-zero fill so it can be located unambiguously
+	//afterwards, unlike the all-zero random/session-id fields elsewhere in
+	//the record.
+	placeholderPayload := bytes.Repeat([]byte{0xff}, len(innerBody)+16)
+
+	echData := echExtensionData(configId, hpkeKdfHKDFSHA256, uint16(aeadId), enc, placeholderPayload)
+	outerRecord := buildClientHelloRecord(
+		[]uint16{0x1301, 0x1302},
+		[]Extension{
+			{Type: 0x0000, Data: sniExtensionData("outer.example.com")},
+			{Type: extTypeECH, Data: echData},
+		},
+	)
+
+	payloadOffset := bytes.Index(outerRecord, placeholderPayload)
+	if payloadOffset < 0 {
+		t.Fatal("could not locate placeholder payload in outer record")
+	}
+	//the AAD is the outer ClientHello with only the ECH payload zeroed; enc,
+	//which the real client has already filled in, stays as-is
+	aad := append([]byte{}, outerRecord...)
+	for i := range placeholderPayload {
+		aad[payloadOffset+i] = 0
+	}
+
+	ciphertext := sealECHForTest(t, privE, privR.PublicKey(), uint16(aeadId), info, aad, innerBody)
+	if len(ciphertext) != len(placeholderPayload) {
+		t.Fatalf("ciphertext length = %d, want %d", len(ciphertext), len(placeholderPayload))
+	}
+	copy(outerRecord[payloadOffset:], ciphertext)
+
+	_, fpCtx := CreateContext(context.Background())
+	fpCtx.SetClientHelloData(outerRecord)
+
+	inner, err := fpCtx.DecryptECH(ECHKeyPair{
+		ConfigId:   configId,
+		PrivateKey: privR.Bytes(),
+		Info:       info,
+	})
+	if err != nil {
+		t.Fatalf("DecryptECH() error: %v", err)
+	}
+	if len(inner.CipherSuites) != 2 || inner.CipherSuites[0] != 0x1301 || inner.CipherSuites[1] != 0x1302 {
+		t.Fatalf("decrypted inner hello has wrong cipher suites: %v", inner.CipherSuites)
+	}
+	if len(inner.Extensions) != 1 || inner.Extensions[0].Type != 0x0000 {
+		t.Fatalf("decrypted inner hello has wrong extensions: %v", inner.Extensions)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 	"time"
@@ -17,10 +19,17 @@ import (
 )
 
 type FpContextData struct {
-	clientHelloData []byte
-	h2Ja3Spec       H2Ja3Spec
-	connectionState tls.ConnectionState
-	orderHeaders    []string
+	clientHelloData  []byte
+	h2Ja3Spec        H2Ja3Spec
+	connectionState  tls.ConnectionState
+	orderHeaders     []string
+	serverHelloData  []byte
+	requestHeaders   *http.Request
+	handshakeLatency time.Duration
+	peerCertificates []*x509.Certificate
+	tcpOptions       TCPOptions
+	quicInitialData  []byte
+	h3Ja3Spec        H3Ja3Spec
 }
 
 func GetFpContextData(ctx context.Context) (*FpContextData, bool) {
@@ -66,6 +75,7 @@ type TlsData struct {
 	RandomBytes        string
 	SessionId          string
 	CompressionMethods string
+	ECH                string //"outer", "inner", or "" when absent
 }
 
 func (obj TlsData) Fp() (string, string) {
@@ -101,6 +111,27 @@ func clearGreas(values []uint16) []uint16 {
 	return results
 }
 
+// extTypeServerName and extTypeALPN identify the two ClientHello extensions
+// JA4 excludes from its ja4a extension count and ja4c hash: both are already
+// represented elsewhere in ja4a (the d/i byte and the ALPN code).
+const (
+	extTypeServerName uint16 = 0x0000
+	extTypeALPN       uint16 = 0x0010
+)
+
+// ja4Extensions returns the extension types JA4 hashes: GREASE values and
+// the SNI/ALPN extensions stripped out.
+func ja4Extensions(values []uint16) []uint16 {
+	results := []uint16{}
+	for _, value := range clearGreas(values) {
+		if value == extTypeServerName || value == extTypeALPN {
+			continue
+		}
+		results = append(results, value)
+	}
+	return results
+}
+
 func (obj ClientHello) TlsData() (tlsData TlsData) {
 	tlsData.Ciphers = obj.CipherSuites
 	tlsData.Curves = obj.Curves()
@@ -119,6 +150,13 @@ func (obj ClientHello) TlsData() (tlsData TlsData) {
 	tlsData.RandomBytes = tools.Hex(obj.RandomBytes)
 	tlsData.SessionId = tools.Hex(obj.SessionId)
 	tlsData.CompressionMethods = tools.Hex(obj.CompressionMethods)
+	if echInfo, ok := obj.ECH(); ok {
+		if echInfo.Outer {
+			tlsData.ECH = "outer"
+		} else {
+			tlsData.ECH = "inner"
+		}
+	}
 	return
 }
 
@@ -285,7 +323,10 @@ func (obj *FpContextData) Ja4() string {
 	default:
 		ja4aStr += "00"
 	}
-	if obj.connectionState.ServerName == "" {
+	if echInfo, ok := rawClientHello.ECH(); ok && echInfo.Outer {
+		//the true SNI is hidden inside the encrypted inner hello
+		ja4aStr += "i"
+	} else if obj.connectionState.ServerName == "" {
 		ja4aStr += "i"
 	} else if _, addTyp := gtls.ParseHost(obj.connectionState.ServerName); addTyp != 0 {
 		ja4aStr += "i"
@@ -293,29 +334,51 @@ func (obj *FpContextData) Ja4() string {
 		ja4aStr += "d"
 	}
 	ciphers := clearGreas(clientHelloParseData.Ciphers)
-	ja4aStr += fmt.Sprint(len(ciphers))
-	exts := clearGreas(clientHelloParseData.Extensions)
-	ja4aStr += fmt.Sprint(len(exts))
-	switch len(obj.connectionState.NegotiatedProtocol) {
+	ja4aStr += ja4CountStr(len(ciphers))
+	exts := ja4Extensions(clientHelloParseData.Extensions)
+	ja4aStr += ja4CountStr(len(exts))
+	ja4aStr += alpnJa4Chars(obj.connectionState.NegotiatedProtocol)
+	sort.Slice(ciphers, func(i, j int) bool { return ciphers[i] < ciphers[j] })
+	sort.Slice(exts, func(i, j int) bool { return exts[i] < exts[j] })
+	ja4bStr := tools.Hex(sha256.Sum256([]byte(hexJoinUint16(ciphers))))[:12]
+	ja4cStr := tools.Hex(sha256.Sum256([]byte(hexJoinUint16(exts) + "_" + hexJoinUint16(clientHelloParseData.Algorithms))))[:12]
+	ja4 := tools.AnyJoin([]string{ja4aStr, ja4bStr, ja4cStr}, "_")
+	return ja4
+}
+
+// alpnJa4Chars renders a negotiated ALPN protocol as JA4a's two-character
+// code: the protocol itself when it's 1-2 chars (padded with "0"), otherwise
+// its first and last character (e.g. "http/1.1" -> "h1").
+func alpnJa4Chars(protocol string) string {
+	switch len(protocol) {
 	case 0:
-		ja4aStr += "00"
+		return "00"
 	case 1:
-		ja4aStr += obj.connectionState.NegotiatedProtocol + "0"
+		return protocol + "0"
 	case 2:
-		ja4aStr += obj.connectionState.NegotiatedProtocol
+		return protocol
 	default:
-		if obj.connectionState.NegotiatedProtocol == "http/1.1" {
-			ja4aStr += "h1"
-		} else {
-			ja4aStr += obj.connectionState.NegotiatedProtocol[:2]
-		}
+		return string(protocol[0]) + string(protocol[len(protocol)-1])
 	}
-	sort.Slice(ciphers, func(i, j int) bool { return ciphers[i] < ciphers[j] })
-	sort.Slice(exts, func(i, j int) bool { return exts[i] < exts[j] })
-	ja4bStr := tools.Hex(sha256.Sum256([]byte(tools.AnyJoin(ciphers, ""))))[:12]
-	ja4cStr := tools.Hex(sha256.Sum256([]byte(tools.AnyJoin(exts, "") + tools.AnyJoin(clientHelloParseData.Algorithms, ""))))[:12]
-	ja4 := tools.AnyJoin([]string{ja4aStr, ja4bStr, ja4cStr}, "_")
-	return ja4
+}
+
+// ja4CountStr renders a JA4 cipher/extension count: zero-padded to two
+// digits and capped at 99, the canonical JA4 encoding for counts >= 100.
+func ja4CountStr(n int) string {
+	if n > 99 {
+		n = 99
+	}
+	return fmt.Sprintf("%02d", n)
+}
+
+// hexJoinUint16 renders values as comma-separated, zero-padded lowercase hex,
+// the canonical JA4 encoding for cipher/extension/signature-algorithm lists.
+func hexJoinUint16(values []uint16) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%04x", value)
+	}
+	return strings.Join(parts, ",")
 }
 func (obj *FpContextData) ConnectionState() tls.ConnectionState {
 	return obj.connectionState
@@ -353,6 +416,22 @@ func (obj *FpContextData) SetPriority(data Priority) {
 	obj.h2Ja3Spec.Priority = data
 }
 
+func (obj *FpContextData) SetServerHello(data []byte) {
+	obj.serverHelloData = data
+}
+func (obj *FpContextData) SetRequestHeaders(req *http.Request) {
+	obj.requestHeaders = req
+}
+func (obj *FpContextData) SetHandshakeLatency(val time.Duration) {
+	obj.handshakeLatency = val
+}
+func (obj *FpContextData) SetPeerCertificates(certs []*x509.Certificate) {
+	obj.peerCertificates = certs
+}
+func (obj *FpContextData) SetTCPOptions(opts TCPOptions) {
+	obj.tcpOptions = opts
+}
+
 type keyPrincipal string
 
 const keyPrincipalID keyPrincipal = "FpContextData"
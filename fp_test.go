@@ -0,0 +1,204 @@
+package ja3
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestAlpnJa4Chars(t *testing.T) {
+	cases := []struct {
+		protocol string
+		want     string
+	}{
+		{"", "00"},
+		{"h", "h0"},
+		{"h2", "h2"},
+		{"http/1.1", "h1"},
+		{"h3-29", "h9"},
+	}
+	for _, c := range cases {
+		if got := alpnJa4Chars(c.protocol); got != c.want {
+			t.Errorf("alpnJa4Chars(%q) = %q, want %q", c.protocol, got, c.want)
+		}
+	}
+}
+
+func TestHexJoinUint16(t *testing.T) {
+	cases := []struct {
+		values []uint16
+		want   string
+	}{
+		{nil, ""},
+		{[]uint16{0x1301}, "1301"},
+		{[]uint16{0x1301, 0x1302, 0xc02b}, "1301,1302,c02b"},
+	}
+	for _, c := range cases {
+		if got := hexJoinUint16(c.values); got != c.want {
+			t.Errorf("hexJoinUint16(%v) = %q, want %q", c.values, got, c.want)
+		}
+	}
+}
+
+func TestJa4Extensions(t *testing.T) {
+	got := ja4Extensions([]uint16{0x0000, 0x000a, 0x0a0a, 0x0010, 0x000d})
+	want := []uint16{0x000a, 0x000d}
+	if len(got) != len(want) {
+		t.Fatalf("ja4Extensions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ja4Extensions() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestJa4CountStr(t *testing.T) {
+	cases := []struct {
+		n    int
+		want string
+	}{
+		{0, "00"},
+		{3, "03"},
+		{9, "09"},
+		{10, "10"},
+		{99, "99"},
+		{150, "99"},
+	}
+	for _, c := range cases {
+		if got := ja4CountStr(c.n); got != c.want {
+			t.Errorf("ja4CountStr(%d) = %q, want %q", c.n, got, c.want)
+		}
+	}
+}
+
+// TestJa4 is a golden-vector regression test for the hashing bugs fixed in
+// Ja4 (missing separators in ja4b/ja4c, unpadded ja4a counts, SNI/ALPN
+// leaking into the extension count and ja4c hash): it builds a fully
+// deterministic ClientHello fixture and asserts the exact JA4 string, so a
+// future regression in hexJoinUint16/sorting/GREASE-clearing/extension
+// filtering will be caught immediately instead of only showing up as a
+// subtly wrong fingerprint.
+func TestJa4(t *testing.T) {
+	record := buildClientHelloRecord(
+		[]uint16{0x0a0a, 0x1301, 0x1302, 0xc02b}, //first is GREASE
+		[]Extension{
+			{Type: 0x0000, Data: sniExtensionData("example.com")},
+			{Type: 0x000a, Data: curvesExtensionData([]uint16{0x001d})},
+			{Type: 0x000b, Data: pointsExtensionData([]uint8{0x00})},
+			{Type: 0x000d, Data: sigAlgsExtensionData([]uint16{0x0403, 0x0804})},
+			{Type: 0x0010, Data: alpnExtensionData([]string{"h2"})},
+			{Type: 0x002b, Data: versionsExtensionData([]uint16{0x0304})},
+			{Type: 0x0a0a, Data: nil}, //GREASE extension
+		},
+	)
+	_, fpCtx := CreateContext(context.Background())
+	fpCtx.SetClientHelloData(record)
+	fpCtx.SetConnectionState(tls.ConnectionState{
+		Version:            tls.VersionTLS13,
+		ServerName:         "example.com",
+		NegotiatedProtocol: "h2",
+	})
+	want := "t13d0304h2_5559582ccdc4_fb71836bce29"
+	if got := fpCtx.Ja4(); got != want {
+		t.Errorf("Ja4() = %q, want %q", got, want)
+	}
+}
+
+// TestJa4S is a golden-vector regression test for the ALPN-abbreviation bug
+// fixed in Ja4S: a negotiated protocol of "http/1.1" must collapse to "h1",
+// not the first-two-characters "ht" the buggy version produced.
+func TestJa4S(t *testing.T) {
+	record := buildServerHelloRecord(0xc02b, []Extension{
+		{Type: 0x0010, Data: nil},
+		{Type: 0x002b, Data: nil},
+	})
+	_, fpCtx := CreateContext(context.Background())
+	fpCtx.SetServerHello(record)
+	fpCtx.SetConnectionState(tls.ConnectionState{
+		Version:            tls.VersionTLS13,
+		NegotiatedProtocol: "http/1.1",
+	})
+	got := fpCtx.Ja4S()
+	want := "t13" + "02" + "h1"
+	if len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("Ja4S() = %q, want prefix %q", got, want)
+	}
+}
+
+// TestJa4HDeterministic is a regression test for the map-iteration bug fixed
+// in Ja4H: calling it twice on the same request must always produce the same
+// ja4b, not a value that changes with Go's randomized map iteration order.
+func TestJa4HDeterministic(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("Accept-Language", "en-US")
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Cookie", "a=b")
+
+	_, fpCtx := CreateContext(context.Background())
+	fpCtx.SetRequestHeaders(req)
+
+	first := fpCtx.Ja4H()
+	for i := 0; i < 20; i++ {
+		if got := fpCtx.Ja4H(); got != first {
+			t.Fatalf("Ja4H() is nondeterministic: got %q, want %q (iteration %d)", got, first, i)
+		}
+	}
+}
+
+// TestJa4HAcceptLanguagePadding is a regression test for the
+// Accept-Language padding bug fixed in Ja4H: a short (but non-empty)
+// language tag like "en" must be zero-padded to 4 characters, not left
+// short.
+func TestJa4HAcceptLanguagePadding(t *testing.T) {
+	cases := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "0000"},
+		{"en", "en00"},
+		{"en-US", "enus"},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "https://example.com/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", c.acceptLanguage)
+		}
+		_, fpCtx := CreateContext(context.Background())
+		fpCtx.SetRequestHeaders(req)
+		got := fpCtx.Ja4H()
+		if len(got) < 12 || got[8:12] != c.want {
+			t.Errorf("Ja4H() with Accept-Language %q = %q, want lang segment %q", c.acceptLanguage, got, c.want)
+		}
+	}
+}
+
+// TestJa4HOrderHeaders asserts Ja4H honors the captured wire order from
+// SetOrderHeaders rather than re-deriving an order from the header map.
+func TestJa4HOrderHeaders(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Accept", "text/html")
+
+	_, withoutOrder := CreateContext(context.Background())
+	withoutOrder.SetRequestHeaders(req)
+
+	_, withOrder := CreateContext(context.Background())
+	withOrder.SetRequestHeaders(req)
+	withOrder.SetOrderHeaders([]string{"User-Agent", "Accept"})
+
+	if withoutOrder.Ja4H() == withOrder.Ja4H() {
+		t.Fatal("Ja4H() ja4b should change when SetOrderHeaders supplies a different header order")
+	}
+}
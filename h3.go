@@ -0,0 +1,23 @@
+package ja3
+
+// H3Ja3Spec is the HTTP/3 sibling of H2Ja3Spec: the SETTINGS frame sent on
+// the control stream plus the QPACK dynamic table parameters negotiated
+// alongside it.
+type H3Ja3Spec struct {
+	Settings              []Setting
+	QPACKMaxTableCapacity uint64
+	QPACKBlockedStreams   uint64
+}
+
+func (obj *FpContextData) H3Ja3Spec() H3Ja3Spec {
+	return obj.h3Ja3Spec
+}
+func (obj *FpContextData) SetH3Settings(data []Setting) {
+	obj.h3Ja3Spec.Settings = data
+}
+func (obj *FpContextData) SetQPACKMaxTableCapacity(val uint64) {
+	obj.h3Ja3Spec.QPACKMaxTableCapacity = val
+}
+func (obj *FpContextData) SetQPACKBlockedStreams(val uint64) {
+	obj.h3Ja3Spec.QPACKBlockedStreams = val
+}
@@ -0,0 +1,184 @@
+package ja3
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// encodeQUICVarint encodes v as a QUIC variable-length integer (RFC 9000
+// section 16), the exact inverse of readVarint.
+func encodeQUICVarint(v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return []byte{byte(v)}
+	case v < 1<<14:
+		b := make([]byte, 2)
+		binary.BigEndian.PutUint16(b, uint16(v))
+		b[0] |= 0x40
+		return b
+	case v < 1<<30:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(v))
+		b[0] |= 0x80
+		return b
+	default:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v)
+		b[0] |= 0xc0
+		return b
+	}
+}
+
+// buildClientHelloRecord assembles a minimal but well-formed TLS record
+// carrying a ClientHello, the exact inverse of decodeClientHello, for use as
+// test fixtures.
+func buildClientHelloRecord(cipherSuites []uint16, extensions []Extension) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8(0x16)
+	b.AddUint16(0x0301)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(1) //client_hello
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			addClientHelloInnerBody(b, cipherSuites, extensions)
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+// buildClientHelloInnerBody builds an EncodedClientHelloInner body (the
+// handshake message body with no record/handshake header of its own), as
+// carried raw by a QUIC CRYPTO frame or an ECH payload.
+func buildClientHelloInnerBody(cipherSuites []uint16, extensions []Extension) []byte {
+	var b cryptobyte.Builder
+	addClientHelloInnerBody(&b, cipherSuites, extensions)
+	return b.BytesOrPanic()
+}
+
+func addClientHelloInnerBody(b *cryptobyte.Builder, cipherSuites []uint16, extensions []Extension) {
+	b.AddUint16(0x0303)
+	b.AddBytes(make([]byte, 4))                              //random time
+	b.AddBytes(make([]byte, 28))                             //random bytes
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {}) //session id
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, cipher := range cipherSuites {
+			b.AddUint16(cipher)
+		}
+	})
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(0) //null compression
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, ext := range extensions {
+			b.AddUint16(ext.Type)
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(ext.Data)
+			})
+		}
+	})
+}
+
+// buildServerHelloRecord assembles a minimal TLS record carrying a
+// ServerHello, the exact inverse of decodeServerHello.
+func buildServerHelloRecord(cipherSuite uint16, extensions []Extension) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8(0x16)
+	b.AddUint16(0x0301)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(2) //server_hello
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint16(0x0303)
+			b.AddBytes(make([]byte, 4))
+			b.AddBytes(make([]byte, 28))
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {})
+			b.AddUint16(cipherSuite)
+			b.AddUint8(0)
+			if len(extensions) > 0 {
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					for _, ext := range extensions {
+						b.AddUint16(ext.Type)
+						b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+							b.AddBytes(ext.Data)
+						})
+					}
+				})
+			}
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+// sniExtensionData and the helpers below build the wire-format body for a
+// few well-known extensions,
+// matching the encodings utls' createExtension parses.
+func sniExtensionData(host string) []byte {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(0) //host_name
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(host))
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+func curvesExtensionData(curves []uint16) []byte {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, curve := range curves {
+			b.AddUint16(curve)
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+func pointsExtensionData(points []uint8) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(points)
+	})
+	return b.BytesOrPanic()
+}
+
+func sigAlgsExtensionData(algorithms []uint16) []byte {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, algorithm := range algorithms {
+			b.AddUint16(algorithm)
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+func alpnExtensionData(protocols []string) []byte {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, protocol := range protocols {
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes([]byte(protocol))
+			})
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+func versionsExtensionData(versions []uint16) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, version := range versions {
+			b.AddUint16(version)
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+func quicTransportParametersExtensionData(params map[uint64][]byte, order []uint64) []byte {
+	var out []byte
+	for _, id := range order {
+		value := params[id]
+		out = append(out, encodeQUICVarint(id)...)
+		out = append(out, encodeQUICVarint(uint64(len(value)))...)
+		out = append(out, value...)
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package ja3
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+//go:embed fingerprints.json
+var fingerprintData []byte
+
+// FingerprintEntry is one named browser/client fingerprint in the database.
+// Entries can be regenerated from mirror captures with cmd/ja3gen.
+type FingerprintEntry struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	JA3        string   `json:"ja3"`
+	JA3N       string   `json:"ja3n"`
+	JA4        string   `json:"ja4"`
+	Ciphers    []uint16 `json:"ciphers"`
+	Extensions []uint16 `json:"extensions"`
+	Curves     []uint16 `json:"curves"`
+	Points     []uint16 `json:"points"`
+	Protocols  []string `json:"protocols"`
+	Versions   []uint16 `json:"versions"`
+	Algorithms []uint16 `json:"algorithms"`
+}
+
+var fingerprintDB []FingerprintEntry
+
+func init() {
+	if err := json.Unmarshal(fingerprintData, &fingerprintDB); err != nil {
+		panic("ja3: embedded fingerprint dataset is invalid: " + err.Error())
+	}
+}
+
+// Match is the result of identifying a TlsData against the fingerprint database.
+type Match struct {
+	Name    string
+	Version string
+	Score   float64 //1 on an exact JA3/JA3N/JA4 hit, otherwise a 0-1 similarity score
+	Exact   bool
+	Diffs   []string
+}
+
+// Identify matches the observed ClientHello against the fingerprint database,
+// returning the best scoring entry along with human readable diagnostic
+// differences explaining why it isn't an exact match.
+func (obj *FpContextData) Identify() (Match, error) {
+	if len(fingerprintDB) == 0 {
+		return Match{}, errors.New("ja3: fingerprint database is empty")
+	}
+	clientHello, err := obj.ClientHello()
+	if err != nil {
+		return Match{}, err
+	}
+	tlsData := clientHello.TlsData()
+	ja3Str, ja3nStr := tlsData.Fp()
+	ja4Str := obj.Ja4()
+
+	var best Match
+	bestScore := -1.0
+	for _, entry := range fingerprintDB {
+		if entry.JA3 == ja3Str || entry.JA3N == ja3nStr || entry.JA4 == ja4Str {
+			return Match{Name: entry.Name, Version: entry.Version, Score: 1, Exact: true}, nil
+		}
+		score := scoreEntry(entry, tlsData)
+		if score > bestScore {
+			bestScore = score
+			best = Match{Name: entry.Name, Version: entry.Version, Score: score, Diffs: diffEntry(entry, tlsData)}
+		}
+	}
+	return best, nil
+}
+
+// scoreEntry weighs cipher/extension set overlap (Jaccard) alongside
+// order-preserving extension similarity (LCS), since JA3 order shifts
+// between browser releases while the underlying multiset tends to hold.
+func scoreEntry(entry FingerprintEntry, tlsData TlsData) float64 {
+	cipherScore := jaccardUint16(entry.Ciphers, tlsData.Ciphers)
+	extensionScore := jaccardUint16(entry.Extensions, tlsData.Extensions)
+	orderScore := lcsRatioUint16(entry.Extensions, tlsData.Extensions)
+	return cipherScore*0.35 + extensionScore*0.35 + orderScore*0.3
+}
+
+func jaccardUint16(a, b []uint16) float64 {
+	setA := toSetUint16(a)
+	setB := toSetUint16(b)
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+	intersection := 0
+	for value := range setA {
+		if setB[value] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 1
+	}
+	return float64(intersection) / float64(union)
+}
+
+func lcsRatioUint16(a, b []uint16) float64 {
+	maxLen := len(a)
+	if len(b) > maxLen {
+		maxLen = len(b)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+	return float64(lcsLenUint16(a, b)) / float64(maxLen)
+}
+
+func lcsLenUint16(a, b []uint16) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				dp[i][j] = dp[i-1][j-1] + 1
+			} else if dp[i-1][j] > dp[i][j-1] {
+				dp[i][j] = dp[i-1][j]
+			} else {
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
+
+func toSetUint16(values []uint16) map[uint16]bool {
+	set := make(map[uint16]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func diffEntry(entry FingerprintEntry, tlsData TlsData) []string {
+	msgs := []string{}
+	entrySet := toSetUint16(entry.Extensions)
+	gotSet := toSetUint16(tlsData.Extensions)
+	for value := range gotSet {
+		if !entrySet[value] {
+			msgs = append(msgs, fmt.Sprintf("extension %d present but absent in %s %s", value, entry.Name, entry.Version))
+		}
+	}
+	for value := range entrySet {
+		if !gotSet[value] {
+			msgs = append(msgs, fmt.Sprintf("extension %d expected by %s %s but absent", value, entry.Name, entry.Version))
+		}
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+// SpecDiff is a single field's difference between two TlsData values.
+type SpecDiff struct {
+	Field     string
+	Added     []string
+	Removed   []string
+	Reordered bool
+}
+
+// DiffSpec compares two TlsData values field by field, reporting added,
+// removed and reordered elements. Fields with no difference are omitted.
+func DiffSpec(a, b TlsData) []SpecDiff {
+	diffs := []SpecDiff{}
+	diffs = append(diffs, diffField("ciphers", uint16sToStrings(a.Ciphers), uint16sToStrings(b.Ciphers))...)
+	diffs = append(diffs, diffField("extensions", uint16sToStrings(a.Extensions), uint16sToStrings(b.Extensions))...)
+	diffs = append(diffs, diffField("curves", uint16sToStrings(a.Curves), uint16sToStrings(b.Curves))...)
+	diffs = append(diffs, diffField("points", uint16sToStrings(a.Points), uint16sToStrings(b.Points))...)
+	diffs = append(diffs, diffField("protocols", a.Protocols, b.Protocols)...)
+	diffs = append(diffs, diffField("versions", uint16sToStrings(a.Versions), uint16sToStrings(b.Versions))...)
+	return diffs
+}
+
+func uint16sToStrings(values []uint16) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = fmt.Sprint(value)
+	}
+	return out
+}
+
+func diffField(field string, a, b []string) []SpecDiff {
+	setA := make(map[string]bool, len(a))
+	for _, value := range a {
+		setA[value] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, value := range b {
+		setB[value] = true
+	}
+	added := []string{}
+	for _, value := range b {
+		if !setA[value] {
+			added = append(added, value)
+		}
+	}
+	removed := []string{}
+	for _, value := range a {
+		if !setB[value] {
+			removed = append(removed, value)
+		}
+	}
+	reordered := len(added) == 0 && len(removed) == 0 && !sameOrder(a, b)
+	if len(added) == 0 && len(removed) == 0 && !reordered {
+		return nil
+	}
+	return []SpecDiff{{Field: field, Added: added, Removed: removed, Reordered: reordered}}
+}
+
+func sameOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+package ja3
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gospider007/tools"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// ServerHello is the decoded form of a raw TLS ServerHello message, the
+// server-side counterpart of ClientHello used to compute JA4S.
+type ServerHello struct {
+	ContentType       uint8             //contentType
+	MessageVersion    uint16            //MessageVersion
+	HandshakeVersion  uint16            //HandshakeVersion
+	HandShakeType     uint8             //HandShakeType
+	RandomTime        uint32            //RandomTime
+	RandomBytes       []byte            //RandomBytes
+	SessionId         cryptobyte.String //sessionId
+	CipherSuite       uint16            //negotiated cipherSuite
+	CompressionMethod uint8             //negotiated CompressionMethod
+	Extensions        []Extension
+}
+
+func decodeServerHello(serverhello []byte) (serverHelloInfo ServerHello, err error) {
+	plaintext := cryptobyte.String(serverhello)
+	if !plaintext.ReadUint8(&serverHelloInfo.ContentType) {
+		err = errors.New("contentType error")
+		return
+	}
+	if !plaintext.ReadUint16(&serverHelloInfo.MessageVersion) {
+		err = errors.New("tlsMinVersion error")
+		return
+	}
+	var handShakeProtocol cryptobyte.String
+	if !plaintext.ReadUint16LengthPrefixed(&handShakeProtocol) {
+		err = errors.New("handShakeProtocol error")
+		return
+	}
+	if !handShakeProtocol.ReadUint8(&serverHelloInfo.HandShakeType) {
+		err = errors.New("handShakeType error")
+		return
+	}
+	var handShakeData cryptobyte.String
+	if !handShakeProtocol.ReadUint24LengthPrefixed(&handShakeData) {
+		err = errors.New("handShakeData error")
+		return
+	}
+	if !handShakeData.ReadUint16(&serverHelloInfo.HandshakeVersion) {
+		err = errors.New("tlsMaxVersion error")
+		return
+	}
+	if !handShakeData.ReadUint32(&serverHelloInfo.RandomTime) {
+		err = errors.New("randomTime error")
+		return
+	}
+	if !handShakeData.ReadBytes(&serverHelloInfo.RandomBytes, 28) {
+		err = errors.New("randomTime error")
+		return
+	}
+	if !handShakeData.ReadUint8LengthPrefixed(&serverHelloInfo.SessionId) {
+		err = errors.New("sessionId error")
+		return
+	}
+	if !handShakeData.ReadUint16(&serverHelloInfo.CipherSuite) {
+		err = errors.New("cipherSuite error")
+		return
+	}
+	if !handShakeData.ReadUint8(&serverHelloInfo.CompressionMethod) {
+		err = errors.New("compressionMethod error")
+		return
+	}
+	serverHelloInfo.Extensions = []Extension{}
+	if handShakeData.Empty() {
+		return
+	}
+	var extensionsData cryptobyte.String
+	if !handShakeData.ReadUint16LengthPrefixed(&extensionsData) {
+		err = errors.New("handShakeData error")
+		return
+	}
+	for !extensionsData.Empty() {
+		var extension uint16
+		var extData cryptobyte.String
+		if extensionsData.ReadUint16(&extension) && extensionsData.ReadUint16LengthPrefixed(&extData) {
+			serverHelloInfo.Extensions = append(serverHelloInfo.Extensions, Extension{
+				Type: extension,
+				Data: extData,
+			})
+		}
+	}
+	return
+}
+
+// ServerHello decodes the raw bytes supplied via SetServerHello.
+func (obj *FpContextData) ServerHello() (ServerHello, error) {
+	return decodeServerHello(obj.serverHelloData)
+}
+
+// Ja4S computes the JA4S server fingerprint from the negotiated TLS version,
+// ALPN, cipher and extensions observed in the ServerHello / ConnectionState.
+func (obj *FpContextData) Ja4S() string {
+	serverHello, err := obj.ServerHello()
+	if err != nil {
+		return ""
+	}
+	ja4aStr := "t"
+	switch obj.connectionState.Version {
+	case tls.VersionTLS10:
+		ja4aStr += "10"
+	case tls.VersionTLS11:
+		ja4aStr += "11"
+	case tls.VersionTLS12:
+		ja4aStr += "12"
+	case tls.VersionTLS13:
+		ja4aStr += "13"
+	default:
+		ja4aStr += "00"
+	}
+	exts := []uint16{}
+	for _, extension := range serverHello.Extensions {
+		exts = append(exts, extension.Type)
+	}
+	ja4aStr += ja4CountStr(len(exts))
+	ja4aStr += alpnJa4Chars(obj.connectionState.NegotiatedProtocol)
+	ja4bStr := hexJoinUint16([]uint16{serverHello.CipherSuite})
+	extsOrdered := make([]uint16, len(exts))
+	copy(extsOrdered, exts)
+	ja4cStr := tools.Hex(sha256.Sum256([]byte(hexJoinUint16(extsOrdered))))[:12]
+	return tools.AnyJoin([]string{ja4aStr, ja4bStr, ja4cStr}, "_")
+}
+
+// TCPOptions carries the raw TCP SYN characteristics used to compute JA4T.
+type TCPOptions struct {
+	Window  uint16
+	MSS     uint16
+	Options []uint8 //ordered TCP option kinds, as observed on the wire
+}
+
+// Ja4T computes the JA4T TCP fingerprint from the window size, MSS and
+// ordered TCP option kinds supplied via SetTCPOptions.
+func (obj *FpContextData) Ja4T() string {
+	opts := make([]string, len(obj.tcpOptions.Options))
+	for i, option := range obj.tcpOptions.Options {
+		opts[i] = fmt.Sprint(option)
+	}
+	return tools.AnyJoin([]string{
+		fmt.Sprint(obj.tcpOptions.Window),
+		fmt.Sprint(obj.tcpOptions.MSS),
+		strings.Join(opts, "-"),
+	}, "_")
+}
+
+// Ja4L computes the JA4L light fingerprint from the measured TLS handshake
+// round-trip latency supplied via SetHandshakeLatency.
+func (obj *FpContextData) Ja4L() string {
+	return fmt.Sprintf("%d_c", obj.handshakeLatency.Microseconds())
+}
+
+// Ja4X computes the JA4X certificate-chain fingerprint from the issuer,
+// subject and extension OIDs of each certificate supplied via
+// SetPeerCertificates.
+func (obj *FpContextData) Ja4X() string {
+	certs := obj.peerCertificates
+	if len(certs) == 0 {
+		return ""
+	}
+	parts := make([]string, len(certs))
+	for i, cert := range certs {
+		oids := []string{}
+		for _, name := range cert.Issuer.Names {
+			oids = append(oids, name.Type.String())
+		}
+		for _, name := range cert.Subject.Names {
+			oids = append(oids, name.Type.String())
+		}
+		for _, extension := range cert.Extensions {
+			oids = append(oids, extension.Id.String())
+		}
+		sort.Strings(oids)
+		parts[i] = tools.Hex(sha256.Sum256([]byte(strings.Join(oids, ","))))[:12]
+	}
+	return strings.Join(parts, "_")
+}
+
+// Ja4H computes the JA4H HTTP client fingerprint from the request supplied
+// via SetRequestHeaders.
+func (obj *FpContextData) Ja4H() string {
+	req := obj.requestHeaders
+	if req == nil {
+		return ""
+	}
+	method := strings.ToLower(req.Method)
+	if len(method) > 2 {
+		method = method[:2]
+	}
+	version := "11"
+	switch {
+	case req.ProtoMajor == 2:
+		version = "20"
+	case req.ProtoMajor == 1 && req.ProtoMinor == 0:
+		version = "10"
+	}
+	cookiePresent := "n"
+	if len(req.Cookies()) > 0 {
+		cookiePresent = "c"
+	}
+	refererPresent := "n"
+	if req.Referer() != "" {
+		refererPresent = "r"
+	}
+	lang := strings.ToLower(strings.ReplaceAll(req.Header.Get("Accept-Language"), "-", ""))
+	switch {
+	case len(lang) > 4:
+		lang = lang[:4]
+	case len(lang) < 4:
+		lang += strings.Repeat("0", 4-len(lang))
+	}
+	ja4aStr := fmt.Sprintf("%s%s%s%s%02d%s", method, version, cookiePresent, refererPresent, len(req.Header), lang)
+
+	// req.Header is a map, so ranging over it directly would make ja4b
+	// nondeterministic; use the wire order captured via SetOrderHeaders when
+	// available, otherwise fall back to a stable alphabetical order.
+	var headerNames []string
+	if len(obj.orderHeaders) > 0 {
+		headerNames = make([]string, len(obj.orderHeaders))
+		for i, name := range obj.orderHeaders {
+			headerNames[i] = strings.ToLower(name)
+		}
+	} else {
+		headerNames = make([]string, 0, len(req.Header))
+		for name := range req.Header {
+			headerNames = append(headerNames, strings.ToLower(name))
+		}
+		sort.Strings(headerNames)
+	}
+	ja4bStr := tools.Hex(sha256.Sum256([]byte(strings.Join(headerNames, ","))))[:12]
+
+	ja4cStr := "000000000000"
+	if cookies := req.Cookies(); len(cookies) > 0 {
+		pairs := make([]string, len(cookies))
+		for i, cookie := range cookies {
+			pairs[i] = cookie.Name + "=" + cookie.Value
+		}
+		sort.Strings(pairs)
+		ja4cStr = tools.Hex(sha256.Sum256([]byte(strings.Join(pairs, ","))))[:12]
+	}
+	return tools.AnyJoin([]string{ja4aStr, ja4bStr, ja4cStr}, "_")
+}
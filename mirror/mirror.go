@@ -0,0 +1,218 @@
+// Package mirror implements a passive TLS listener that captures
+// ClientHellos at scale without requiring callers to wire up
+// crypto/tls.GetConfigForClient plumbing themselves.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/tfyl/ja3"
+)
+
+// Record is a single captured ClientHello, ready to be streamed or stored.
+type Record struct {
+	SourceIP       string      `json:"source_ip"`
+	ServerName     string      `json:"server_name"`
+	NegotiatedALPN string      `json:"negotiated_alpn"`
+	JA3            string      `json:"ja3"`
+	JA3N           string      `json:"ja3n"`
+	JA4            string      `json:"ja4"`
+	TlsData        ja3.TlsData `json:"tls_data"`
+	RawHello       string      `json:"raw_hello"` //base64 of the raw ClientHello record
+}
+
+// StorageBackend persists captured records. Implementations are supplied by
+// the caller; StartMirror never assumes a particular store.
+type StorageBackend interface {
+	Store(ctx context.Context, record Record) error
+}
+
+// RateLimiter decides whether a source IP may continue to open connections.
+type RateLimiter interface {
+	Allow(sourceIP string) bool
+}
+
+// Config controls a single StartMirror run.
+type Config struct {
+	Listener    net.Listener
+	Certificate *tls.Certificate //set to upgrade to a real handshake after capture; nil closes the connection after capture
+	Records     chan<- Record    //optional: captured records are sent here, non-blocking
+	Writer      io.Writer        //optional: captured records are written here as newline-delimited JSON
+	Storage     StorageBackend   //optional
+	RateLimiter RateLimiter      //optional: per-source-IP throttling
+
+	// OnClientHello, if set, runs before the handshake continues. Returning
+	// true rejects the connection instead of upgrading/closing normally.
+	OnClientHello func(record Record, conn net.Conn) (reject bool)
+}
+
+// peekConn records every byte read from the underlying connection so the raw
+// ClientHello can be recovered once tls.Server's GetConfigForClient fires.
+type peekConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *peekConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// StartMirror accepts connections on config.Listener until ctx is done or
+// Accept fails, capturing and publishing a Record for every ClientHello seen.
+func StartMirror(ctx context.Context, config Config) error {
+	if config.Listener == nil {
+		return errors.New("mirror: config.Listener is required")
+	}
+	// closing the listener unblocks Accept once ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			config.Listener.Close()
+		case <-done:
+		}
+	}()
+	for {
+		conn, err := config.Listener.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+				return err
+			}
+		}
+		go handleConn(ctx, config, conn)
+	}
+}
+
+func handleConn(ctx context.Context, config Config, conn net.Conn) {
+	defer conn.Close()
+	sourceIP := remoteIP(conn)
+	if config.RateLimiter != nil && !config.RateLimiter.Allow(sourceIP) {
+		return
+	}
+	pc := &peekConn{Conn: conn}
+	_, fpCtx := ja3.CreateContext(ctx)
+	tlsConfig := &tls.Config{
+		GetConfigForClient: func(chi *tls.ClientHelloInfo) (*tls.Config, error) {
+			raw := append([]byte(nil), pc.buf.Bytes()...)
+			fpCtx.SetClientHelloData(raw)
+			negotiatedALPN := ""
+			if len(chi.SupportedProtos) > 0 {
+				negotiatedALPN = chi.SupportedProtos[0]
+			}
+			// the handshake hasn't completed yet, but chi already carries the
+			// SNI JA4a needs to tell a domain destination from an IP one
+			fpCtx.SetConnectionState(tls.ConnectionState{ServerName: chi.ServerName})
+			record := buildRecord(fpCtx, sourceIP, chi.ServerName, negotiatedALPN, raw)
+			publish(ctx, config, record)
+			if config.OnClientHello != nil && config.OnClientHello(record, conn) {
+				return nil, errors.New("mirror: connection rejected by OnClientHello hook")
+			}
+			if config.Certificate == nil {
+				return nil, errors.New("mirror: no certificate configured, closing after capture")
+			}
+			return &tls.Config{Certificates: []tls.Certificate{*config.Certificate}}, nil
+		},
+	}
+	tlsConn := tls.Server(pc, tlsConfig)
+	tlsConn.HandshakeContext(ctx) //error is expected when no certificate is configured; capture already happened
+}
+
+func buildRecord(fpCtx *ja3.FpContextData, sourceIP, serverName, negotiatedALPN string, raw []byte) Record {
+	record := Record{
+		SourceIP:       sourceIP,
+		ServerName:     serverName,
+		NegotiatedALPN: negotiatedALPN,
+		RawHello:       base64.StdEncoding.EncodeToString(raw),
+	}
+	clientHello, err := fpCtx.ClientHello()
+	if err == nil {
+		record.TlsData = clientHello.TlsData()
+		record.JA3, record.JA3N = record.TlsData.Fp()
+	}
+	// JA4T is deliberately not populated here: it requires the raw TCP SYN
+	// options (window, MSS, option order), which a net.Listener-based mirror
+	// has no way to observe without raw sockets/pcap, so there is nothing
+	// real to set it from.
+	record.JA4 = fpCtx.Ja4()
+	return record
+}
+
+func publish(ctx context.Context, config Config, record Record) {
+	if config.Records != nil {
+		select {
+		case config.Records <- record:
+		case <-ctx.Done():
+		default:
+		}
+	}
+	if config.Writer != nil {
+		if data, err := json.Marshal(record); err == nil {
+			config.Writer.Write(append(data, '\n'))
+		}
+	}
+	if config.Storage != nil {
+		config.Storage.Store(ctx, record)
+	}
+}
+
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
+
+// PerIPRateLimiter is a simple sliding-window RateLimiter keyed by source IP.
+type PerIPRateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu   sync.Mutex
+	hits map[string][]time.Time
+}
+
+// NewPerIPRateLimiter allows up to limit connections per source IP within window.
+func NewPerIPRateLimiter(limit int, window time.Duration) *PerIPRateLimiter {
+	return &PerIPRateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[string][]time.Time),
+	}
+}
+
+func (r *PerIPRateLimiter) Allow(sourceIP string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	cutoff := now.Add(-r.window)
+	hits := r.hits[sourceIP][:0]
+	for _, hit := range r.hits[sourceIP] {
+		if hit.After(cutoff) {
+			hits = append(hits, hit)
+		}
+	}
+	if len(hits) >= r.limit {
+		r.hits[sourceIP] = hits
+		return false
+	}
+	r.hits[sourceIP] = append(hits, now)
+	return true
+}
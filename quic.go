@@ -0,0 +1,345 @@
+package ja3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gospider007/tools"
+	"golang.org/x/crypto/hkdf"
+)
+
+// quicV1InitialSalt is the Initial salt for QUIC version 1 (RFC 9001 section 5.2).
+var quicV1InitialSalt = []byte{0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17, 0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a}
+
+const extTypeQUICTransportParameters uint16 = 57
+
+// quicTransportParamGreaseQUICBit is the dedicated id for the
+// grease_quic_bit transport parameter (RFC 9287), not the generic
+// 31*N+27 reserved-parameter pattern many stacks probe with regardless of
+// whether they grease the QUIC header bit.
+const quicTransportParamGreaseQUICBit uint64 = 0x2ab2
+
+// QUICTransportParameters is the typed decode of the quic_transport_parameters
+// extension (type 57) carried inside a QUIC ClientHello. Order preserves the
+// raw parameter ids exactly as they appeared on the wire, for Ja4Q.
+type QUICTransportParameters struct {
+	InitialMaxData                 uint64
+	InitialMaxStreamDataBidiLocal  uint64
+	InitialMaxStreamDataBidiRemote uint64
+	InitialMaxStreamDataUni        uint64
+	ActiveConnectionIdLimit        uint64
+	MaxUDPPayloadSize              uint64
+	DisableActiveMigration         bool
+	VersionInformation             []uint32
+	GreaseQUICBit                  bool
+	Order                          []uint64
+}
+
+// QUICClientHello is the TLS ClientHello recovered from a QUIC Initial
+// packet's CRYPTO frame, alongside its quic_transport_parameters.
+type QUICClientHello struct {
+	TLS                 ClientHello
+	TransportParameters QUICTransportParameters
+}
+
+// SetQUICInitial stores the raw bytes of a client's QUIC Initial packet.
+func (obj *FpContextData) SetQUICInitial(data []byte) {
+	obj.quicInitialData = data
+}
+
+// QUIC decodes the ClientHello carried in the QUIC Initial packet supplied
+// via SetQUICInitial.
+func (obj *FpContextData) QUIC() (QUICClientHello, error) {
+	return decodeQUICClientHello(obj.quicInitialData)
+}
+
+// Ja4Q computes a JA4Q fingerprint for HTTP/3 clients, combining the ordered
+// quic_transport_parameters with the standard JA4 extension/signature-algorithm
+// hash over the embedded TLS ClientHello.
+func (obj *FpContextData) Ja4Q() string {
+	quicHello, err := obj.QUIC()
+	if err != nil {
+		return ""
+	}
+	tlsData := quicHello.TLS.TlsData()
+	ciphers := clearGreas(tlsData.Ciphers)
+	exts := ja4Extensions(tlsData.Extensions)
+	sort.Slice(ciphers, func(i, j int) bool { return ciphers[i] < ciphers[j] })
+	sort.Slice(exts, func(i, j int) bool { return exts[i] < exts[j] })
+
+	tlsVersion := "00"
+	if versions := clearGreas(tlsData.Versions); len(versions) > 0 {
+		switch versions[0] {
+		case tls.VersionTLS13:
+			tlsVersion = "13"
+		case tls.VersionTLS12:
+			tlsVersion = "12"
+		}
+	}
+	qja4aStr := fmt.Sprintf("q%s%s%s", tlsVersion, ja4CountStr(len(ciphers)), ja4CountStr(len(exts)))
+	qja4bStr := tools.Hex(sha256.Sum256([]byte(hexJoinUint64(quicHello.TransportParameters.Order))))[:12]
+	qja4cStr := tools.Hex(sha256.Sum256([]byte(hexJoinUint16(exts) + "_" + hexJoinUint16(tlsData.Algorithms))))[:12]
+	return tools.AnyJoin([]string{qja4aStr, qja4bStr, qja4cStr}, "_")
+}
+
+func hexJoinUint64(values []uint64) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%04x", value)
+	}
+	return tools.AnyJoin(parts, ",")
+}
+
+// decodeQUICClientHello unprotects a single QUIC Initial packet, decrypts its
+// payload and decodes the TLS ClientHello carried in its CRYPTO frame.
+//
+// It assumes the ClientHello fits in one Initial packet's single contiguous
+// CRYPTO frame at offset 0, which holds for essentially all real clients;
+// reassembly across multiple Initial packets is not implemented.
+func decodeQUICClientHello(packet []byte) (QUICClientHello, error) {
+	if len(packet) < 7 {
+		return QUICClientHello{}, errors.New("quic: packet too short")
+	}
+	if packet[0]&0x80 == 0 {
+		return QUICClientHello{}, errors.New("quic: not a long header packet")
+	}
+	if (packet[0]&0x30)>>4 != 0 {
+		return QUICClientHello{}, errors.New("quic: not an Initial packet")
+	}
+
+	offset := 5 //skip first byte + 4-byte version
+	if offset >= len(packet) {
+		return QUICClientHello{}, errors.New("quic: truncated header")
+	}
+	dcidLen := int(packet[offset])
+	offset++
+	if offset+dcidLen > len(packet) {
+		return QUICClientHello{}, errors.New("quic: truncated dcid")
+	}
+	dcid := packet[offset : offset+dcidLen]
+	offset += dcidLen
+	if offset >= len(packet) {
+		return QUICClientHello{}, errors.New("quic: truncated header")
+	}
+	scidLen := int(packet[offset])
+	offset++
+	offset += scidLen
+	if offset > len(packet) {
+		return QUICClientHello{}, errors.New("quic: truncated scid")
+	}
+	tokenLen, offset, err := readVarint(packet, offset)
+	if err != nil {
+		return QUICClientHello{}, fmt.Errorf("quic: token length: %w", err)
+	}
+	offset += int(tokenLen)
+	length, offset, err := readVarint(packet, offset)
+	if err != nil {
+		return QUICClientHello{}, fmt.Errorf("quic: length: %w", err)
+	}
+	pnOffset := offset
+
+	initialSecret := hkdf.Extract(sha256.New, dcid, quicV1InitialSalt)
+	clientSecret := hkdfExpandLabel(initialSecret, []byte("client in"), nil, 32)
+	key := hkdfExpandLabel(clientSecret, []byte("quic key"), nil, 16)
+	ivBytes := hkdfExpandLabel(clientSecret, []byte("quic iv"), nil, 12)
+	hp := hkdfExpandLabel(clientSecret, []byte("quic hp"), nil, 16)
+
+	if pnOffset+4+16 > len(packet) {
+		return QUICClientHello{}, errors.New("quic: packet too short to sample header protection")
+	}
+	hpBlock, err := aes.NewCipher(hp)
+	if err != nil {
+		return QUICClientHello{}, err
+	}
+	mask := make([]byte, 16)
+	hpBlock.Encrypt(mask, packet[pnOffset+4:pnOffset+4+16])
+
+	unprotected := append([]byte{}, packet...)
+	unprotected[0] ^= mask[0] & 0x0f
+	pnLen := int(unprotected[0]&0x03) + 1
+	for i := 0; i < pnLen; i++ {
+		unprotected[pnOffset+i] ^= mask[1+i]
+	}
+	var pn uint64
+	for i := 0; i < pnLen; i++ {
+		pn = pn<<8 | uint64(unprotected[pnOffset+i])
+	}
+
+	ciphertextLen := int(length) - pnLen
+	if ciphertextLen < 0 || pnOffset+pnLen+ciphertextLen > len(unprotected) {
+		return QUICClientHello{}, errors.New("quic: invalid length field")
+	}
+	aad := unprotected[:pnOffset+pnLen]
+	ciphertext := unprotected[pnOffset+pnLen : pnOffset+pnLen+ciphertextLen]
+
+	nonce := make([]byte, len(ivBytes))
+	pnBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(pnBytes, pn)
+	copy(nonce[len(nonce)-8:], pnBytes)
+	for i := range nonce {
+		nonce[i] ^= ivBytes[i]
+	}
+
+	payloadBlock, err := aes.NewCipher(key)
+	if err != nil {
+		return QUICClientHello{}, err
+	}
+	aead, err := cipher.NewGCM(payloadBlock)
+	if err != nil {
+		return QUICClientHello{}, err
+	}
+	payload, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return QUICClientHello{}, fmt.Errorf("quic: aead open: %w", err)
+	}
+
+	cryptoData, err := extractCryptoFrame(payload)
+	if err != nil {
+		return QUICClientHello{}, err
+	}
+	clientHello, err := decodeClientHello(wrapHandshakeRecord(cryptoData))
+	if err != nil {
+		return QUICClientHello{}, fmt.Errorf("quic: decoding tls client hello: %w", err)
+	}
+	return QUICClientHello{
+		TLS:                 clientHello,
+		TransportParameters: extractQUICTransportParameters(clientHello),
+	}, nil
+}
+
+func extractCryptoFrame(payload []byte) ([]byte, error) {
+	offset := 0
+	var crypto []byte
+	found := false
+	for offset < len(payload) {
+		frameType, next, err := readVarint(payload, offset)
+		if err != nil {
+			break
+		}
+		offset = next
+		switch frameType {
+		case 0x00, 0x01: //PADDING, PING
+			continue
+		case 0x06: //CRYPTO
+			cryptoOffset, o2, err := readVarint(payload, offset)
+			if err != nil {
+				return nil, fmt.Errorf("quic: crypto frame offset: %w", err)
+			}
+			cryptoLen, o3, err := readVarint(payload, o2)
+			if err != nil {
+				return nil, fmt.Errorf("quic: crypto frame length: %w", err)
+			}
+			if cryptoOffset != 0 && found {
+				return nil, errors.New("quic: reassembly of non-contiguous CRYPTO frames is not supported")
+			}
+			if o3+int(cryptoLen) > len(payload) {
+				return nil, errors.New("quic: crypto frame overruns payload")
+			}
+			crypto = payload[o3 : o3+int(cryptoLen)]
+			found = true
+			offset = o3 + int(cryptoLen)
+		default:
+			return crypto, nil //stop at the first frame type we don't need (e.g. ACK)
+		}
+	}
+	if !found {
+		return nil, errors.New("quic: no CRYPTO frame found in Initial packet")
+	}
+	return crypto, nil
+}
+
+func extractQUICTransportParameters(clientHello ClientHello) QUICTransportParameters {
+	var params QUICTransportParameters
+	for _, ext := range clientHello.Extensions {
+		if ext.Type != extTypeQUICTransportParameters {
+			continue
+		}
+		data := []byte(ext.Data)
+		offset := 0
+		for offset < len(data) {
+			id, o1, err := readVarint(data, offset)
+			if err != nil {
+				break
+			}
+			paramLen, o2, err := readVarint(data, o1)
+			if err != nil {
+				break
+			}
+			if o2+int(paramLen) > len(data) {
+				break
+			}
+			value := data[o2 : o2+int(paramLen)]
+			params.Order = append(params.Order, id)
+			switch id {
+			case 0x03:
+				params.MaxUDPPayloadSize = firstVarint(value)
+			case 0x04:
+				params.InitialMaxData = firstVarint(value)
+			case 0x05:
+				params.InitialMaxStreamDataBidiLocal = firstVarint(value)
+			case 0x06:
+				params.InitialMaxStreamDataBidiRemote = firstVarint(value)
+			case 0x07:
+				params.InitialMaxStreamDataUni = firstVarint(value)
+			case 0x0c:
+				params.DisableActiveMigration = true
+			case 0x0e:
+				params.ActiveConnectionIdLimit = firstVarint(value)
+			case 0x11:
+				for i := 0; i+4 <= len(value); i += 4 {
+					params.VersionInformation = append(params.VersionInformation, binary.BigEndian.Uint32(value[i:i+4]))
+				}
+			case quicTransportParamGreaseQUICBit:
+				params.GreaseQUICBit = true
+			}
+			offset = o2 + int(paramLen)
+		}
+		break
+	}
+	return params
+}
+
+func firstVarint(value []byte) uint64 {
+	v, _, err := readVarint(value, 0)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// readVarint decodes a QUIC variable-length integer (RFC 9000 section 16)
+// starting at offset, returning the value and the offset just past it.
+func readVarint(data []byte, offset int) (value uint64, newOffset int, err error) {
+	if offset >= len(data) {
+		return 0, 0, errors.New("varint: out of range")
+	}
+	length := 1 << (data[offset] >> 6)
+	if offset+length > len(data) {
+		return 0, 0, errors.New("varint: truncated")
+	}
+	value = uint64(data[offset] & 0x3f)
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(data[offset+i])
+	}
+	return value, offset + length, nil
+}
+
+func hkdfExpandLabel(secret, label, context []byte, length int) []byte {
+	info := make([]byte, 2)
+	binary.BigEndian.PutUint16(info, uint16(length))
+	fullLabel := append([]byte("tls13 "), label...)
+	info = append(info, byte(len(fullLabel)))
+	info = append(info, fullLabel...)
+	info = append(info, byte(len(context)))
+	info = append(info, context...)
+	out := make([]byte, length)
+	io.ReadFull(hkdf.Expand(sha256.New, secret, info), out)
+	return out
+}
@@ -0,0 +1,154 @@
+package ja3
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+func buildQUICCryptoFrame(cryptoData []byte) []byte {
+	var out []byte
+	out = append(out, encodeQUICVarint(0x06)...) //CRYPTO
+	out = append(out, encodeQUICVarint(0)...)    //offset
+	out = append(out, encodeQUICVarint(uint64(len(cryptoData)))...)
+	out = append(out, cryptoData...)
+	return out
+}
+
+// sealQUICInitialForTest builds a single-packet QUIC v1 Initial packet
+// carrying payload (already including any CRYPTO/PADDING frames), protected
+// the same way decodeQUICClientHello expects to unprotect it. pn is always
+// encoded as a single byte (pnLen 1), which is enough to exercise the
+// varint/header-protection/AEAD plumbing under test.
+func sealQUICInitialForTest(t *testing.T, dcid, payload []byte) []byte {
+	t.Helper()
+	initialSecret := hkdf.Extract(sha256.New, dcid, quicV1InitialSalt)
+	clientSecret := hkdfExpandLabel(initialSecret, []byte("client in"), nil, 32)
+	key := hkdfExpandLabel(clientSecret, []byte("quic key"), nil, 16)
+	ivBytes := hkdfExpandLabel(clientSecret, []byte("quic iv"), nil, 12)
+	hp := hkdfExpandLabel(clientSecret, []byte("quic hp"), nil, 16)
+
+	const pnLen = 1
+	const pn = 0
+
+	header := []byte{0xc0}                          //long header, fixed bit, Initial type, pnLen-1 = 0
+	header = append(header, 0x00, 0x00, 0x00, 0x01) //version 1
+	header = append(header, byte(len(dcid)))
+	header = append(header, dcid...)
+	header = append(header, 0x00)                   //scid length 0
+	header = append(header, encodeQUICVarint(0)...) //token length 0
+	lengthField := encodeQUICVarint(uint64(pnLen + len(payload) + 16))
+	header = append(header, lengthField...)
+	pnOffset := len(header)
+	header = append(header, byte(pn)) //packet number, 1 byte
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, len(ivBytes))
+	copy(nonce, ivBytes)
+	nonce[len(nonce)-1] ^= pn
+
+	aad := header
+	ciphertext := aead.Seal(nil, nonce, payload, aad)
+
+	packet := append(append([]byte{}, header...), ciphertext...)
+
+	sampleStart := pnOffset + 4
+	sample := packet[sampleStart : sampleStart+16]
+	hpBlock, err := aes.NewCipher(hp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask := make([]byte, 16)
+	hpBlock.Encrypt(mask, sample)
+
+	packet[0] ^= mask[0] & 0x0f
+	packet[pnOffset] ^= mask[1]
+	return packet
+}
+
+// TestDecodeQUICClientHelloRoundTrip exercises the full QUIC Initial decrypt
+// path (header protection removal, packet-number/length varint decoding,
+// AEAD payload decryption, CRYPTO frame extraction) and asserts the typed
+// transport parameters, including GreaseQUICBit being set by the dedicated
+// grease_quic_bit parameter id.
+func TestDecodeQUICClientHelloRoundTrip(t *testing.T) {
+	innerBody := buildClientHelloInnerBody(
+		[]uint16{0x1301, 0x1302},
+		[]Extension{
+			{Type: 0x0000, Data: sniExtensionData("quic.example.com")},
+			{
+				Type: extTypeQUICTransportParameters,
+				Data: quicTransportParametersExtensionData(
+					map[uint64][]byte{
+						0x03:   encodeQUICVarint(1200), //max_udp_payload_size
+						0x0c:   {},                     //disable_active_migration
+						0x0e:   encodeQUICVarint(2),    //active_connection_id_limit
+						0x2ab2: {},                     //grease_quic_bit
+					},
+					[]uint64{0x03, 0x0c, 0x0e, 0x2ab2},
+				),
+			},
+		},
+	)
+	payload := buildQUICCryptoFrame(innerBody)
+	dcid := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	packet := sealQUICInitialForTest(t, dcid, payload)
+
+	_, fpCtx := CreateContext(context.Background())
+	fpCtx.SetQUICInitial(packet)
+
+	quicHello, err := fpCtx.QUIC()
+	if err != nil {
+		t.Fatalf("QUIC() error: %v", err)
+	}
+	if len(quicHello.TLS.CipherSuites) != 2 || quicHello.TLS.CipherSuites[0] != 0x1301 {
+		t.Fatalf("recovered TLS ClientHello has wrong cipher suites: %v", quicHello.TLS.CipherSuites)
+	}
+	params := quicHello.TransportParameters
+	if !params.DisableActiveMigration {
+		t.Error("DisableActiveMigration = false, want true")
+	}
+	if params.ActiveConnectionIdLimit != 2 {
+		t.Errorf("ActiveConnectionIdLimit = %d, want 2", params.ActiveConnectionIdLimit)
+	}
+	if params.MaxUDPPayloadSize != 1200 {
+		t.Errorf("MaxUDPPayloadSize = %d, want 1200", params.MaxUDPPayloadSize)
+	}
+	if !params.GreaseQUICBit {
+		t.Error("GreaseQUICBit = false, want true for the dedicated grease_quic_bit id 0x2ab2")
+	}
+}
+
+// TestExtractQUICTransportParametersIgnoresGenericReservedIds is the direct
+// regression test for the review fix: a generic 31*N+27 reserved/GREASE
+// parameter id that is NOT the dedicated grease_quic_bit id must not flip
+// GreaseQUICBit.
+func TestExtractQUICTransportParametersIgnoresGenericReservedIds(t *testing.T) {
+	const genericReservedId = 31*2 + 27 //an RFC 9000 §18.1 reserved id, distinct from 0x2ab2
+	clientHello := ClientHello{
+		Extensions: []Extension{
+			{
+				Type: extTypeQUICTransportParameters,
+				Data: quicTransportParametersExtensionData(
+					map[uint64][]byte{genericReservedId: {}},
+					[]uint64{genericReservedId},
+				),
+			},
+		},
+	}
+	params := extractQUICTransportParameters(clientHello)
+	if params.GreaseQUICBit {
+		t.Error("GreaseQUICBit = true for a generic reserved parameter id, want false")
+	}
+}
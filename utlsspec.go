@@ -0,0 +1,38 @@
+package ja3
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// UtlsSpec converts a decoded ClientHello back into a utls.ClientHelloSpec,
+// preserving cipher order, extension order (GREASE placeholders included, in
+// their original positions) and compression methods, so a captured hello can
+// be replayed with utls.UClient without hand-writing a spec. Extension types
+// createExtension doesn't recognize are kept as utls.GenericExtension with
+// their raw bytes intact.
+func (obj ClientHello) UtlsSpec() (utls.ClientHelloSpec, error) {
+	if len(obj.CipherSuites) == 0 {
+		return utls.ClientHelloSpec{}, errors.New("client hello has no cipher suites")
+	}
+	extensions := make([]utls.TLSExtension, 0, len(obj.Extensions))
+	for _, ext := range obj.Extensions {
+		utlsExt, ok := createExtension(ext.Type, extensionOption{data: ext.Data})
+		if !ok || utlsExt == nil {
+			utlsExt = &utls.GenericExtension{Id: ext.Type, Data: append([]byte{}, ext.Data...)}
+		}
+		extensions = append(extensions, utlsExt)
+	}
+	compressionMethods := append([]byte{}, obj.CompressionMethods...)
+	if len(compressionMethods) == 0 {
+		compressionMethods = []byte{0}
+	}
+	return utls.ClientHelloSpec{
+		CipherSuites:       obj.CipherSuites,
+		CompressionMethods: compressionMethods,
+		Extensions:         extensions,
+		GetSessionID:       sha256.Sum256,
+	}, nil
+}